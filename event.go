@@ -0,0 +1,116 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+)
+
+// Port : defines a port to be opened on the elb
+type Port struct {
+	FromPort int    `json:"from_port"`
+	ToPort   int    `json:"to_port"`
+	Protocol string `json:"protocol"`
+}
+
+// Event stores the elb.delete.aws message
+type Event struct {
+	UUID                string   `json:"_uuid"`
+	BatchID             string   `json:"_batch_id"`
+	ProviderType        string   `json:"_type"`
+	VPCID               string   `json:"vpc_id"`
+	DatacenterRegion    string   `json:"datacenter_region"`
+	DatacenterSecret    string   `json:"datacenter_secret"`
+	DatacenterToken     string   `json:"datacenter_token"`
+	ELBName             string   `json:"elb_name"`
+	ELBType             string   `json:"elb_type"`
+	ELBIsPrivate        bool     `json:"elb_is_private"`
+	ELBPorts            []Port   `json:"elb_ports"`
+	NetworkAWSIDs       []string `json:"network_aws_ids"`
+	InstanceAWSIDs      []string `json:"instance_aws_ids"`
+	SecurityGroupAWSIDs []string `json:"security_group_aws_ids"`
+	DeregisterInstances bool     `json:"deregister_instances"`
+	DrainTimeoutSeconds int      `json:"drain_timeout_seconds"`
+	ErrorMessage        string   `json:"error,omitempty"`
+}
+
+// Process : unmarshals the event data
+func (ev *Event) Process(data []byte) error {
+	return json.Unmarshal(data, ev)
+}
+
+// Validate checks if all criteria are met
+func (ev *Event) Validate() error {
+	if ev.VPCID == "" {
+		return errors.New("Datacenter VPC ID invalid")
+	}
+	if ev.DatacenterRegion == "" {
+		return errors.New("Datacenter Region invalid")
+	}
+	if ev.DatacenterSecret == "" || ev.DatacenterToken == "" {
+		return errors.New("Datacenter credentials invalid")
+	}
+	if ev.ELBName == "" {
+		return errors.New("ELB name is invalid")
+	}
+	switch ev.ELBType {
+	case "", "classic", "application", "network":
+	default:
+		return errors.New("ELB type is invalid")
+	}
+	return nil
+}
+
+// Error : Logs an error and notifies elb.delete.aws.error
+func (ev *Event) Error(err error) {
+	extra := map[string]interface{}{}
+	if reqErr, ok := err.(awserr.RequestFailure); ok {
+		extra["aws_request_id"] = reqErr.RequestID()
+	}
+	logJSON(ev, "error", err.Error(), extra)
+
+	ev.ErrorMessage = err.Error()
+
+	data, err := json.Marshal(ev)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	nc.Publish("elb.delete.aws.error", data)
+}
+
+// Progress : Notifies elb.delete.aws.progress with a status update
+func (ev *Event) Progress(message string) {
+	data, err := json.Marshal(struct {
+		UUID    string `json:"_uuid"`
+		BatchID string `json:"_batch_id"`
+		ELBName string `json:"elb_name"`
+		Message string `json:"message"`
+	}{
+		UUID:    ev.UUID,
+		BatchID: ev.BatchID,
+		ELBName: ev.ELBName,
+		Message: message,
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+
+	nc.Publish("elb.delete.aws.progress", data)
+}
+
+// Complete : Notifies elb.delete.aws.done
+func (ev *Event) Complete() {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	nc.Publish("elb.delete.aws.done", data)
+}
@@ -0,0 +1,219 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package main
+
+import (
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elb/elbiface"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+)
+
+// instanceHealthPollInterval is how often DescribeInstanceHealth is polled
+// while draining instances out of a classic ELB before it is deleted. It is
+// a var so tests can shrink it instead of waiting out the real interval.
+var instanceHealthPollInterval = 5 * time.Second
+
+// LoadBalancerDeleter deletes a single load balancer on behalf of an Event
+type LoadBalancerDeleter interface {
+	Delete(ev *Event) error
+}
+
+// newLoadBalancerDeleter builds the LoadBalancerDeleter for ev.ELBType. It is
+// a package-level var so tests can inject a mock deleter.
+var newLoadBalancerDeleter = func(ev *Event) (LoadBalancerDeleter, error) {
+	creds := credentials.NewStaticCredentials(ev.DatacenterSecret, ev.DatacenterToken, "")
+	cfg := &aws.Config{
+		Region:      aws.String(ev.DatacenterRegion),
+		Credentials: creds,
+	}
+	sess := session.New()
+	logAPICalls(sess, ev)
+
+	switch ev.ELBType {
+	case "", "classic":
+		return &ClassicELBDeleter{svc: elb.New(sess, cfg)}, nil
+	case "application", "network":
+		return &ELBv2Deleter{svc: elbv2.New(sess, cfg)}, nil
+	default:
+		return nil, errors.New("ELB type is invalid")
+	}
+}
+
+// logAPICalls wires a request.Handlers hook onto sess that logs each AWS API
+// call's service, operation, latency and retry count, so slow deletes can be
+// debugged in aggregated log pipelines without cross-referencing NATS.
+func logAPICalls(sess *session.Session, ev *Event) {
+	sess.Handlers.Complete.PushBack(func(r *request.Request) {
+		logAPICallCompleted(ev, r.ClientInfo.ServiceName, r.Operation.Name, time.Since(r.Time), r.RetryCount, r.Error)
+	})
+}
+
+// logAPICallCompleted logs a single finished AWS API call. Split out from
+// logAPICalls so it can be exercised without constructing a *request.Request.
+func logAPICallCompleted(ev *Event, service, operation string, latency time.Duration, retryCount int, apiErr error) {
+	extra := map[string]interface{}{
+		"aws_service":   service,
+		"aws_operation": operation,
+		"latency_ms":    latency.Milliseconds(),
+		"retry_count":   retryCount,
+	}
+	if apiErr != nil {
+		extra["error"] = apiErr.Error()
+	}
+
+	logJSON(ev, "debug", "aws api call completed", extra)
+}
+
+// ClassicELBDeleter deletes classic (v1) ELBs
+type ClassicELBDeleter struct {
+	svc elbiface.ELBAPI
+}
+
+// Delete drains registered instances (when requested) and removes a classic ELB
+func (d *ClassicELBDeleter) Delete(ev *Event) error {
+	if ev.DeregisterInstances && len(ev.InstanceAWSIDs) > 0 {
+		if err := d.drain(ev); err != nil {
+			return err
+		}
+	}
+
+	req := elb.DeleteLoadBalancerInput{
+		LoadBalancerName: aws.String(ev.ELBName),
+	}
+
+	_, err := d.svc.DeleteLoadBalancer(&req)
+	return err
+}
+
+// drain deregisters ev.InstanceAWSIDs from the ELB and waits for them to
+// report OutOfService, up to ev.DrainTimeoutSeconds, before returning so the
+// load balancer can be safely deleted without dropping in-flight requests.
+func (d *ClassicELBDeleter) drain(ev *Event) error {
+	instances := make([]*elb.Instance, len(ev.InstanceAWSIDs))
+	for i, id := range ev.InstanceAWSIDs {
+		instances[i] = &elb.Instance{InstanceId: aws.String(id)}
+	}
+
+	ev.Progress("deregistering instances from " + ev.ELBName)
+
+	_, err := d.svc.DeregisterInstancesFromLoadBalancer(&elb.DeregisterInstancesFromLoadBalancerInput{
+		LoadBalancerName: aws.String(ev.ELBName),
+		Instances:        instances,
+	})
+	if err != nil {
+		return err
+	}
+
+	timeout := time.Duration(ev.DrainTimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		return nil
+	}
+
+	ev.Progress("waiting for connections to drain from " + ev.ELBName)
+
+	deadline := time.Now().Add(timeout)
+	for {
+		resp, err := d.svc.DescribeInstanceHealth(&elb.DescribeInstanceHealthInput{
+			LoadBalancerName: aws.String(ev.ELBName),
+			Instances:        instances,
+		})
+		if err != nil {
+			return err
+		}
+
+		if allOutOfService(resp.InstanceStates) {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return nil
+		}
+
+		wait := instanceHealthPollInterval
+		if remaining := time.Until(deadline); remaining < wait {
+			wait = remaining
+		}
+		time.Sleep(wait)
+	}
+}
+
+// allOutOfService reports whether every instance state is OutOfService
+func allOutOfService(states []*elb.InstanceState) bool {
+	for _, s := range states {
+		if s.State == nil || *s.State != "OutOfService" {
+			return false
+		}
+	}
+	return true
+}
+
+// ELBv2Deleter deletes application/network (v2) load balancers, tearing down
+// their listeners and target groups before removing the load balancer itself
+type ELBv2Deleter struct {
+	svc *elbv2.ELBV2
+}
+
+// Delete removes listeners, then target groups, and then the load balancer.
+// Listeners (and the rules referencing a target group) must go first: AWS
+// rejects DeleteTargetGroup with ResourceInUseException while any listener
+// still references it.
+func (d *ELBv2Deleter) Delete(ev *Event) error {
+	lbs, err := d.svc.DescribeLoadBalancers(&elbv2.DescribeLoadBalancersInput{
+		Names: []*string{aws.String(ev.ELBName)},
+	})
+	if err != nil {
+		return err
+	}
+	if len(lbs.LoadBalancers) == 0 {
+		return errors.New("load balancer not found: " + ev.ELBName)
+	}
+	lbARN := lbs.LoadBalancers[0].LoadBalancerArn
+
+	listeners, err := d.svc.DescribeListeners(&elbv2.DescribeListenersInput{
+		LoadBalancerArn: lbARN,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Target groups are looked up while the listeners still associate them
+	// with the load balancer.
+	targetGroups, err := d.svc.DescribeTargetGroups(&elbv2.DescribeTargetGroupsInput{
+		LoadBalancerArn: lbARN,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, l := range listeners.Listeners {
+		_, err = d.svc.DeleteListener(&elbv2.DeleteListenerInput{
+			ListenerArn: l.ListenerArn,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, tg := range targetGroups.TargetGroups {
+		_, err = d.svc.DeleteTargetGroup(&elbv2.DeleteTargetGroupInput{
+			TargetGroupArn: tg.TargetGroupArn,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = d.svc.DeleteLoadBalancer(&elbv2.DeleteLoadBalancerInput{
+		LoadBalancerArn: lbARN,
+	})
+	return err
+}
@@ -0,0 +1,125 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elb/elbiface"
+	"github.com/nats-io/nats"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeELBClient implements elbiface.ELBAPI, overriding only the calls
+// ClassicELBDeleter makes; any other method panics via the nil embed.
+type fakeELBClient struct {
+	elbiface.ELBAPI
+
+	deregisterCalls int
+	deregisterErr   error
+
+	healthStates []string
+	healthErr    error
+
+	deleteCalls int
+}
+
+func (f *fakeELBClient) DeregisterInstancesFromLoadBalancer(in *elb.DeregisterInstancesFromLoadBalancerInput) (*elb.DeregisterInstancesFromLoadBalancerOutput, error) {
+	f.deregisterCalls++
+	return &elb.DeregisterInstancesFromLoadBalancerOutput{}, f.deregisterErr
+}
+
+func (f *fakeELBClient) DescribeInstanceHealth(in *elb.DescribeInstanceHealthInput) (*elb.DescribeInstanceHealthOutput, error) {
+	if f.healthErr != nil {
+		return nil, f.healthErr
+	}
+
+	states := make([]*elb.InstanceState, len(f.healthStates))
+	for i, s := range f.healthStates {
+		states[i] = &elb.InstanceState{State: aws.String(s)}
+	}
+
+	return &elb.DescribeInstanceHealthOutput{InstanceStates: states}, nil
+}
+
+func (f *fakeELBClient) DeleteLoadBalancer(in *elb.DeleteLoadBalancerInput) (*elb.DeleteLoadBalancerOutput, error) {
+	f.deleteCalls++
+	return &elb.DeleteLoadBalancerOutput{}, nil
+}
+
+func TestClassicELBDeleterDelete(t *testing.T) {
+	originalPollInterval := instanceHealthPollInterval
+	instanceHealthPollInterval = time.Millisecond
+	defer func() { instanceHealthPollInterval = originalPollInterval }()
+
+	Convey("Given a classic ELB deleter with instances to deregister", t, func() {
+		e := testEvent
+		e.DeregisterInstances = true
+		e.DrainTimeoutSeconds = 1
+
+		Convey("When all instances drain before the timeout", func() {
+			fake := &fakeELBClient{healthStates: []string{"OutOfService"}}
+			d := &ClassicELBDeleter{svc: fake}
+
+			err := d.Delete(&e)
+
+			Convey("It should deregister, skip waiting further, and delete", func() {
+				So(err, ShouldBeNil)
+				So(fake.deregisterCalls, ShouldEqual, 1)
+				So(fake.deleteCalls, ShouldEqual, 1)
+			})
+		})
+
+		Convey("When the timeout expires while instances are still InService", func() {
+			fake := &fakeELBClient{healthStates: []string{"InService"}}
+			d := &ClassicELBDeleter{svc: fake}
+
+			err := d.Delete(&e)
+
+			Convey("It should still delete the load balancer", func() {
+				So(err, ShouldBeNil)
+				So(fake.deregisterCalls, ShouldEqual, 1)
+				So(fake.deleteCalls, ShouldEqual, 1)
+			})
+		})
+
+		Convey("When DescribeInstanceHealth errors", func() {
+			fake := &fakeELBClient{healthErr: errors.New("describe failed")}
+			d := &ClassicELBDeleter{svc: fake}
+
+			err := d.Delete(&e)
+
+			Convey("It should short-circuit and not delete the load balancer", func() {
+				So(err, ShouldNotBeNil)
+				So(fake.deregisterCalls, ShouldEqual, 1)
+				So(fake.deleteCalls, ShouldEqual, 0)
+			})
+		})
+
+		Convey("When draining", func() {
+			testSetup()
+			progress := make(chan *nats.Msg, 10)
+			nc.ChanSubscribe("elb.delete.aws.progress", progress)
+
+			fake := &fakeELBClient{healthStates: []string{"OutOfService"}}
+			d := &ClassicELBDeleter{svc: fake}
+
+			err := d.Delete(&e)
+
+			Convey("It should publish status updates on elb.delete.aws.progress", func() {
+				So(err, ShouldBeNil)
+
+				msg, timeout := waitMsg(progress)
+				So(timeout, ShouldBeNil)
+				So(msg, ShouldNotBeNil)
+				So(string(msg.Data), ShouldContainSubstring, `"elb_name":"test-elb"`)
+			})
+		})
+	})
+}
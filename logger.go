@@ -0,0 +1,48 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+)
+
+func init() {
+	log.SetFlags(0)
+}
+
+// logJSON emits a single structured JSON log line through the standard
+// library's default logger (so log.SetOutput still controls where it goes,
+// e.g. to silence it in tests). When ev is not nil it is enriched with the
+// event's correlation fields (uuid, batch_id, elb_name, datacenter_region)
+// so operators can aggregate a delete's logs across processes without
+// cross-referencing the NATS error payload separately.
+func logJSON(ev *Event, level, message string, extra map[string]interface{}) {
+	fields := map[string]interface{}{
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+		"level":     level,
+		"message":   message,
+	}
+
+	if ev != nil {
+		fields["uuid"] = ev.UUID
+		fields["batch_id"] = ev.BatchID
+		fields["elb_name"] = ev.ELBName
+		fields["datacenter_region"] = ev.DatacenterRegion
+	}
+
+	for k, v := range extra {
+		fields[k] = v
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		log.Println(message)
+		return
+	}
+
+	log.Println(string(data))
+}
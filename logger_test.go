@@ -0,0 +1,95 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func captureLogJSON(fn func()) map[string]interface{} {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stdout)
+
+	fn()
+
+	var fields map[string]interface{}
+	json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &fields)
+	return fields
+}
+
+func TestLogJSON(t *testing.T) {
+	Convey("Given an event", t, func() {
+		e := testEvent
+
+		Convey("When logging with an event and extra fields", func() {
+			fields := captureLogJSON(func() {
+				logJSON(&e, "info", "something happened", map[string]interface{}{"foo": "bar"})
+			})
+
+			Convey("It should enrich the line with the event's correlation fields and the extras", func() {
+				So(fields["level"], ShouldEqual, "info")
+				So(fields["message"], ShouldEqual, "something happened")
+				So(fields["uuid"], ShouldEqual, e.UUID)
+				So(fields["batch_id"], ShouldEqual, e.BatchID)
+				So(fields["elb_name"], ShouldEqual, e.ELBName)
+				So(fields["datacenter_region"], ShouldEqual, e.DatacenterRegion)
+				So(fields["foo"], ShouldEqual, "bar")
+			})
+		})
+
+		Convey("When logging without an event", func() {
+			fields := captureLogJSON(func() {
+				logJSON(nil, "warn", "no event here", nil)
+			})
+
+			Convey("It should omit the correlation fields", func() {
+				So(fields["level"], ShouldEqual, "warn")
+				So(fields["message"], ShouldEqual, "no event here")
+				_, hasUUID := fields["uuid"]
+				So(hasUUID, ShouldBeFalse)
+			})
+		})
+	})
+}
+
+func TestLogAPICallCompleted(t *testing.T) {
+	Convey("Given a completed AWS API call", t, func() {
+		e := testEvent
+
+		Convey("When it succeeds", func() {
+			fields := captureLogJSON(func() {
+				logAPICallCompleted(&e, "elb", "DeleteLoadBalancer", 42*time.Millisecond, 2, nil)
+			})
+
+			Convey("It should log the service, operation, latency and retry count", func() {
+				So(fields["aws_service"], ShouldEqual, "elb")
+				So(fields["aws_operation"], ShouldEqual, "DeleteLoadBalancer")
+				So(fields["retry_count"], ShouldEqual, 2)
+				So(fields["latency_ms"], ShouldEqual, 42)
+				_, hasError := fields["error"]
+				So(hasError, ShouldBeFalse)
+			})
+		})
+
+		Convey("When it fails", func() {
+			fields := captureLogJSON(func() {
+				logAPICallCompleted(&e, "elb", "DeleteLoadBalancer", 0, 0, errors.New("boom"))
+			})
+
+			Convey("It should include the error", func() {
+				So(fields["error"], ShouldEqual, "boom")
+			})
+		})
+	})
+}
@@ -5,18 +5,18 @@
 package main
 
 import (
-	"fmt"
 	"os"
-	"runtime"
+	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/elb"
 	ecc "github.com/ernestio/ernest-config-client"
 	"github.com/nats-io/nats"
 )
 
+const (
+	natsMaxReconnectWait = 30 * time.Second
+	natsConnectRetryWait = 2 * time.Second
+)
+
 var nc *nats.Conn
 var natsErr error
 
@@ -43,30 +43,80 @@ func eventHandler(m *nats.Msg) {
 }
 
 func deleteELB(ev *Event) error {
-	creds := credentials.NewStaticCredentials(ev.DatacenterSecret, ev.DatacenterToken, "")
-	svc := elb.New(session.New(), &aws.Config{
-		Region:      aws.String(ev.DatacenterRegion),
-		Credentials: creds,
-	})
-
-	// Delete Loadbalancer
-	req := elb.DeleteLoadBalancerInput{
-		LoadBalancerName: aws.String(ev.ELBName),
-	}
-
-	_, err := svc.DeleteLoadBalancer(&req)
+	d, err := newLoadBalancerDeleter(ev)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return d.Delete(ev)
+}
+
+// connectNats connects to the given NATS uri, retrying every
+// natsConnectRetryWait until maxWait elapses, and wires up handlers so the connector logs and survives
+// reconnects instead of dying silently. closedCB is set on the connection
+// options before Connect() runs, the same way DisconnectedCB/ReconnectedCB
+// are, so callers never need to mutate a running *nats.Conn's callbacks.
+func connectNats(uri string, maxWait time.Duration, closedCB nats.ConnHandler) (*nats.Conn, error) {
+	opts := nats.DefaultOptions
+	opts.Url = uri
+	opts.MaxReconnect = -1
+	opts.ReconnectWait = natsConnectRetryWait
+	opts.DisconnectedCB = func(c *nats.Conn) {
+		logJSON(nil, "warn", "nats disconnected, attempting to reconnect", nil)
+	}
+	opts.ReconnectedCB = func(c *nats.Conn) {
+		logJSON(nil, "info", "nats reconnected", map[string]interface{}{"url": c.ConnectedUrl()})
+	}
+	opts.ClosedCB = closedCB
+
+	deadline := time.Now().Add(maxWait)
+	var conn *nats.Conn
+	var err error
+	for attempt := 1; ; attempt++ {
+		conn, err = opts.Connect()
+		if err == nil {
+			return conn, nil
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, err
+		}
+
+		logJSON(nil, "warn", "nats connect attempt failed, retrying", map[string]interface{}{
+			"attempt": attempt,
+			"error":   err.Error(),
+		})
+
+		wait := natsConnectRetryWait
+		if remaining < wait {
+			wait = remaining
+		}
+		time.Sleep(wait)
+	}
 }
 
 func main() {
-	nc = ecc.NewConfig(os.Getenv("NATS_URI")).Nats()
+	var err error
+
+	// MaxReconnect is unbounded, so closedCB only fires once the connection
+	// is deliberately closed or gives up for good; block on it instead of
+	// exiting via runtime.Goexit() the moment the subscription is set up.
+	closed := make(chan struct{})
+	closedCB := func(c *nats.Conn) {
+		logJSON(nil, "error", "nats connection closed, shutting down", nil)
+		close(closed)
+	}
+
+	nc, err = connectNats(os.Getenv("NATS_URI"), natsMaxReconnectWait, closedCB)
+	if err != nil {
+		logJSON(nil, "warn", "nats: falling back to ernest-config-client", map[string]interface{}{"error": err.Error()})
+		nc = ecc.NewConfig(os.Getenv("NATS_URI")).Nats()
+		nc.SetClosedHandler(closedCB)
+	}
 
-	fmt.Println("listening for elb.delete.aws")
+	logJSON(nil, "info", "listening for elb.delete.aws", nil)
 	nc.Subscribe("elb.delete.aws", eventHandler)
 
-	runtime.Goexit()
+	<-closed
 }
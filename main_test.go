@@ -0,0 +1,110 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/nats-io/nats"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type mockLoadBalancerDeleter struct {
+	err error
+}
+
+func (m *mockLoadBalancerDeleter) Delete(ev *Event) error {
+	return m.err
+}
+
+func TestDeleteELB(t *testing.T) {
+	original := newLoadBalancerDeleter
+	defer func() { newLoadBalancerDeleter = original }()
+
+	Convey("Given an elb.delete.aws event", t, func() {
+		for _, elbType := range []string{"", "classic", "application", "network"} {
+			elbType := elbType
+
+			Convey("With an ELB type of '"+elbType+"'", func() {
+				e := testEvent
+				e.ELBType = elbType
+
+				Convey("When the delete succeeds", func() {
+					newLoadBalancerDeleter = func(ev *Event) (LoadBalancerDeleter, error) {
+						return &mockLoadBalancerDeleter{}, nil
+					}
+
+					Convey("It should not error", func() {
+						So(deleteELB(&e), ShouldBeNil)
+					})
+				})
+
+				Convey("When the delete fails", func() {
+					newLoadBalancerDeleter = func(ev *Event) (LoadBalancerDeleter, error) {
+						return &mockLoadBalancerDeleter{err: errors.New("delete failed")}, nil
+					}
+
+					Convey("It should return the error", func() {
+						So(deleteELB(&e), ShouldNotBeNil)
+					})
+				})
+			})
+		}
+
+		Convey("With a mix of in and out of service instances", func() {
+			states := []*elb.InstanceState{
+				{InstanceId: aws.String("i-0000000"), State: aws.String("OutOfService")},
+				{InstanceId: aws.String("i-0000001"), State: aws.String("InService")},
+			}
+
+			Convey("It should not consider them drained", func() {
+				So(allOutOfService(states), ShouldBeFalse)
+			})
+		})
+
+		Convey("With every instance out of service", func() {
+			states := []*elb.InstanceState{
+				{InstanceId: aws.String("i-0000000"), State: aws.String("OutOfService")},
+				{InstanceId: aws.String("i-0000001"), State: aws.String("OutOfService")},
+			}
+
+			Convey("It should consider them drained", func() {
+				So(allOutOfService(states), ShouldBeTrue)
+			})
+		})
+
+		Convey("With an invalid ELB type", func() {
+			e := testEvent
+			e.ELBType = "bogus"
+
+			Convey("When validating the event", func() {
+				Convey("It should error", func() {
+					err := e.Validate()
+					So(err, ShouldNotBeNil)
+					So(err.Error(), ShouldEqual, "ELB type is invalid")
+				})
+			})
+		})
+	})
+}
+
+func TestConnectNats(t *testing.T) {
+	Convey("Given an unreachable NATS uri", t, func() {
+		Convey("When connecting with a short maxWait", func() {
+			start := time.Now()
+			_, err := connectNats("nats://127.0.0.1:1", 200*time.Millisecond, func(c *nats.Conn) {})
+			elapsed := time.Since(start)
+
+			Convey("It should return an error without hanging past maxWait", func() {
+				So(err, ShouldNotBeNil)
+				So(elapsed, ShouldBeLessThan, 3*time.Second)
+			})
+		})
+	})
+}